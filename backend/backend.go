@@ -41,3 +41,30 @@ type ValueUnmarshaler interface {
 	// String returns the name of the unmarshaler for comparing with the backend tag value.
 	String() string
 }
+
+// A BatchGetter is implemented by backends that can fetch several keys in a
+// single round-trip. confita.Loader detects it automatically and, for such
+// a backend, issues one GetMany instead of one Get per field, collapsing N
+// sequential round-trips into one. Keys absent from the returned map are
+// treated the same as an ErrNotFound Get.
+type BatchGetter interface {
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// A Decrypter is implemented by backends that store config values encrypted
+// (KMS, Vault, SOPS-style files...). confita.Loader calls Decrypt on the
+// raw value of any field tagged `config:"...,secret"` before unmarshaling
+// it, so the plaintext never has to round-trip through the caller's code.
+// Values a backend serves through ValueUnmarshaler are decoded by the
+// backend itself and are not passed to Decrypt.
+type Decrypter interface {
+	Decrypt(ctx context.Context, key string, ciphertext []byte) ([]byte, error)
+}
+
+// A Watcher is implemented by backends that can push updates for a given key
+// instead of being polled for them. Watch should block, sending the raw
+// value on ch every time it changes, until ctx is canceled or an
+// unrecoverable error occurs.
+type Watcher interface {
+	Watch(ctx context.Context, key string, ch chan<- []byte) error
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Factory wraps the construction of a Backend so it only happens lazily, on
+// the first Get call, instead of when the Backend is registered with
+// NewLoader. This lets callers register backends that dial out to a remote
+// service (Vault, etcd, Consul...) unconditionally, without paying the
+// connection cost, or failing at startup, when that service is not needed
+// yet or temporarily unavailable.
+//
+// The constructor is only ever run once it succeeds; a failed attempt is
+// not memoized, so the next Get retries it.
+func Factory(name string, fn func(ctx context.Context) (Backend, error)) Backend {
+	return &factory{name: name, fn: fn}
+}
+
+type factory struct {
+	name string
+	fn   func(ctx context.Context) (Backend, error)
+
+	ready   int32 // atomic: 1 once backend has been successfully built
+	mu      sync.Mutex
+	backend Backend
+}
+
+func (f *factory) String() string { return f.name }
+
+func (f *factory) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := f.backendFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.Get(ctx, key)
+}
+
+// backendFor returns the built backend, constructing it first if necessary.
+// Concurrent callers during construction all wait on the same attempt
+// instead of racing to dial the remote service multiple times.
+func (f *factory) backendFor(ctx context.Context) (Backend, error) {
+	if atomic.LoadInt32(&f.ready) == 1 {
+		return f.backend, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if atomic.LoadInt32(&f.ready) == 1 {
+		return f.backend, nil
+	}
+
+	b, err := f.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.backend = b
+	atomic.StoreInt32(&f.ready, 1)
+	return b, nil
+}
+
+func (f *factory) build(ctx context.Context) (Backend, error) {
+	type result struct {
+		b   Backend
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		b, err := f.fn(ctx)
+		done <- result{b, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.b, r.err
+	case <-ctx.Done():
+		// fn may have finished at the same instant ctx was canceled, making
+		// both cases ready; select then picks between them pseudo-randomly,
+		// which can otherwise discard a successfully built backend and
+		// force every later Get to rebuild it. Give done one last
+		// non-blocking check before giving up on this attempt.
+		select {
+		case r := <-done:
+			return r.b, r.err
+		default:
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package backend_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heetch/confita/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBackend string
+
+func (s stubBackend) Get(ctx context.Context, key string) ([]byte, error) { return []byte(s), nil }
+func (s stubBackend) String() string                                      { return string(s) }
+
+func TestFactoryMemoizesSuccess(t *testing.T) {
+	var calls int32
+
+	b := backend.Factory("stub", func(ctx context.Context) (backend.Backend, error) {
+		atomic.AddInt32(&calls, 1)
+		return stubBackend("value"), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := b.Get(context.Background(), "key")
+			require.NoError(t, err)
+			require.Equal(t, "value", string(v))
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestFactoryRetriesAfterError(t *testing.T) {
+	var calls int32
+
+	b := backend.Factory("stub", func(ctx context.Context) (backend.Backend, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			return nil, errors.New("temporarily unavailable")
+		}
+		return stubBackend("value"), nil
+	})
+
+	_, err := b.Get(context.Background(), "key")
+	require.Error(t, err)
+
+	v, err := b.Get(context.Background(), "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", string(v))
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestFactoryHonorsContextDuringInit(t *testing.T) {
+	b := backend.Factory("stub", func(ctx context.Context) (backend.Backend, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := b.Get(ctx, "key")
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestFactoryKeepsSuccessWhenContextAlreadyDone(t *testing.T) {
+	var calls int32
+
+	b := backend.Factory("stub", func(ctx context.Context) (backend.Backend, error) {
+		atomic.AddInt32(&calls, 1)
+		return stubBackend("value"), nil
+	})
+
+	// ctx is canceled before fn even has a chance to run, so build's select
+	// always has both cases ready: fn still succeeds (it ignores ctx), and
+	// it must not be discarded just because the caller's context is done,
+	// or every subsequent Get would pay to rebuild it again.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	v, err := b.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", string(v))
+
+	v, err = b.Get(context.Background(), "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", string(v))
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
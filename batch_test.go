@@ -0,0 +1,62 @@
+package confita_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBatchStore implements backend.BatchGetter and counts how many
+// times each of its methods is called, so tests can assert the loader
+// preferred GetMany over per-key Get.
+type countingBatchStore struct {
+	data     map[string]string
+	getCalls int32
+	getManyN int32
+}
+
+func (s *countingBatchStore) String() string { return "counting" }
+
+func (s *countingBatchStore) Get(ctx context.Context, key string) ([]byte, error) {
+	atomic.AddInt32(&s.getCalls, 1)
+
+	v, ok := s.data[key]
+	if !ok {
+		return nil, backend.ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func (s *countingBatchStore) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	atomic.AddInt32(&s.getManyN, 1)
+
+	out := make(map[string][]byte)
+	for _, k := range keys {
+		if v, ok := s.data[k]; ok {
+			out[k] = []byte(v)
+		}
+	}
+	return out, nil
+}
+
+func TestLoadUsesBatchGetter(t *testing.T) {
+	type config struct {
+		A string `config:"a"`
+		B string `config:"b"`
+		C string `config:"c"`
+	}
+
+	s := &countingBatchStore{data: map[string]string{"a": "1", "b": "2", "c": "3"}}
+
+	var cfg config
+	err := confita.NewLoader(s).Load(context.Background(), &cfg)
+	require.NoError(t, err)
+
+	require.Equal(t, config{A: "1", B: "2", C: "3"}, cfg)
+	require.EqualValues(t, 1, atomic.LoadInt32(&s.getManyN))
+	require.EqualValues(t, 0, atomic.LoadInt32(&s.getCalls))
+}
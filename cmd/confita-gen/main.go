@@ -0,0 +1,64 @@
+// Command confita-gen generates, for a struct whose fields carry
+// `config:"..."` tags, a companion file of strongly-typed accessors that
+// bypass confita's reflection pipeline. It is meant to be driven by
+// go:generate, for example:
+//
+//	//go:generate confita-gen -type Config
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/heetch/confita/codegen"
+)
+
+func main() {
+	var (
+		typeName = flag.String("type", "", "name of the target struct (required)")
+		output   = flag.String("output", "", "output file name (default: <type>_confita.go, snake_cased)")
+	)
+	flag.Parse()
+
+	if *typeName == "" {
+		log.Fatal("confita-gen: -type is required")
+	}
+
+	// go:generate always runs with the working directory set to the
+	// package containing the directive, so "." is the target package.
+	s, err := codegen.Load(".", *typeName)
+	if err != nil {
+		log.Fatalf("confita-gen: %v", err)
+	}
+
+	out := *output
+	if out == "" {
+		out = fmt.Sprintf("%s_confita.go", toSnakeCase(*typeName))
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatalf("confita-gen: creating %s: %v", out, err)
+	}
+	defer f.Close()
+
+	if err := codegen.Generate(f, s); err != nil {
+		log.Fatalf("confita-gen: %v", err)
+	}
+}
+
+func toSnakeCase(s string) string {
+	var out []rune
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				out = append(out, '_')
+			}
+			r = r - 'A' + 'a'
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
@@ -0,0 +1,56 @@
+// Package codegen generates, for a struct whose fields carry `config:"..."`
+// tags, a companion file of strongly-typed accessors: a Keys() slice
+// describing every declared key and a Load<Field> helper per field that
+// loads straight from a backend.Backend, without going through the
+// reflection-based Loader. It is the implementation behind the
+// cmd/confita-gen tool.
+package codegen
+
+import "strings"
+
+// Field describes a single tagged field found while walking a target
+// struct. Name is dotted for fields nested in sub-structs, e.g.
+// "Server.Port".
+type Field struct {
+	Name     string
+	Key      string
+	Backend  string
+	Default  string
+	Required bool
+	// Type is the field's Go type written as source, e.g. "string",
+	// "*int", "time.Duration", or "[]string".
+	Type string
+}
+
+// Struct is everything Generate needs to know about the target type.
+type Struct struct {
+	Package string // package name the generated file belongs to
+	Name    string // target struct name
+	Fields  []Field
+}
+
+// parseTag parses a `config` struct tag into its components. It mirrors the
+// tag syntax understood by the confita.Loader: "key[,required][,backend=x]
+// [,default=y]".
+func parseTag(tag string) (key string, backendName string, def string, required bool, ok bool) {
+	if tag == "" || tag == "-" {
+		return "", "", "", false, false
+	}
+
+	parts := strings.Split(tag, ",")
+	key = parts[0]
+	ok = true
+
+	for _, p := range parts[1:] {
+		switch {
+		case p == "required":
+			required = true
+		case strings.HasPrefix(p, "backend="):
+			backendName = strings.TrimPrefix(p, "backend=")
+		case strings.HasPrefix(p, "default="):
+			def = strings.TrimPrefix(p, "default=")
+		}
+	}
+
+	return key, backendName, def, required, ok
+}
@@ -0,0 +1,216 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// KeyInfo describes one config key declared on a generated struct. It is
+// referenced by the Keys() method emitted for every target struct.
+type KeyInfo struct {
+	Path     string
+	Key      string
+	Backend  string
+	Default  string
+	Required bool
+}
+
+// decoder describes how to turn the raw bytes read from a backend into a Go
+// value of a given base type. pkg names the import it relies on, if any.
+// Pointer types (e.g. "*int") and "[]string" are handled on top of this
+// table rather than listed in it; every other slice/map/struct type falls
+// back to the reflection-based Loader and is not given a Load<Field>
+// helper.
+type decoder struct {
+	expr string // Go expression; %s is replaced with the variable holding the raw string
+	pkg  string // import required by expr, if any
+}
+
+var decoders = map[string]decoder{
+	"bool":          {expr: `strconv.ParseBool(%s)`, pkg: "strconv"},
+	"int":           {expr: `strconv.Atoi(%s)`, pkg: "strconv"},
+	"int8":          {expr: `strconv.ParseInt(%s, 10, 8)`, pkg: "strconv"},
+	"int16":         {expr: `strconv.ParseInt(%s, 10, 16)`, pkg: "strconv"},
+	"int32":         {expr: `strconv.ParseInt(%s, 10, 32)`, pkg: "strconv"},
+	"int64":         {expr: `strconv.ParseInt(%s, 10, 64)`, pkg: "strconv"},
+	"uint":          {expr: `strconv.ParseUint(%s, 10, 64)`, pkg: "strconv"},
+	"uint8":         {expr: `strconv.ParseUint(%s, 10, 8)`, pkg: "strconv"},
+	"uint16":        {expr: `strconv.ParseUint(%s, 10, 16)`, pkg: "strconv"},
+	"uint32":        {expr: `strconv.ParseUint(%s, 10, 32)`, pkg: "strconv"},
+	"uint64":        {expr: `strconv.ParseUint(%s, 10, 64)`, pkg: "strconv"},
+	"float32":       {expr: `strconv.ParseFloat(%s, 32)`, pkg: "strconv"},
+	"float64":       {expr: `strconv.ParseFloat(%s, 64)`, pkg: "strconv"},
+	"time.Duration": {expr: `time.ParseDuration(%s)`, pkg: "time"},
+}
+
+// decodableType reports whether Generate can emit a Load<Field> helper for
+// typ: a type in decoders, "string", "[]string" (split on commas), or a
+// pointer to any of those.
+func decodableType(typ string) bool {
+	if typ == "string" || typ == "[]string" {
+		return true
+	}
+	if strings.HasPrefix(typ, "*") {
+		elem := strings.TrimPrefix(typ, "*")
+		return !strings.HasPrefix(elem, "*") && decodableType(elem)
+	}
+	_, ok := decoders[typ]
+	return ok
+}
+
+const tmplSrc = `// Code generated by confita-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{if .NeedLoadHelpers}}	"context"
+{{end}}{{if .NeedStrconv}}	"strconv"
+{{end}}{{if .NeedStrings}}	"strings"
+{{end}}{{if .NeedTime}}	"time"
+{{end}}
+{{if .NeedLoadHelpers}}	"github.com/heetch/confita/backend"
+{{end}}	"github.com/heetch/confita/codegen"
+)
+
+// Keys returns every config key declared on {{.Name}}.
+func (*{{.Name}}) Keys() []codegen.KeyInfo {
+	return []codegen.KeyInfo{
+{{- range .Fields}}
+		{Path: {{quote .Name}}, Key: {{quote .Key}}, Backend: {{quote .Backend}}, Default: {{quote .Default}}, Required: {{.Required}}},
+{{- end}}
+	}
+}
+{{range .Fields}}
+{{if decodable .Type}}
+// Load{{fieldFunc .Name}} loads the {{.Name}} field of {{$.Name}} directly
+// from b, skipping the reflection pipeline used by confita.Loader.
+func Load{{fieldFunc .Name}}(ctx context.Context, b backend.Backend) ({{.Type}}, error) {
+	var zero {{.Type}}
+
+	data, err := b.Get(ctx, {{quote .Key}})
+	if err != nil {
+{{if .Default}}		if err != backend.ErrNotFound {
+			return zero, err
+		}
+		data = []byte({{quote .Default}})
+{{else if .Required}}		return zero, err
+{{else}}		if err != backend.ErrNotFound {
+			return zero, err
+		}
+		return zero, nil
+{{end}}	}
+
+	{{decodeStmt .Type "string(data)"}}
+}
+{{end}}
+{{- end}}
+`
+
+// templateData augments a Struct with the set of imports its generated
+// Load<Field> helpers need, computed once up front so the template itself
+// stays free of that logic.
+type templateData struct {
+	*Struct
+	NeedLoadHelpers bool // at least one field gets a Load<Field> helper
+	NeedStrconv     bool
+	NeedStrings     bool
+	NeedTime        bool
+}
+
+// Generate writes the companion file for s to w.
+func Generate(w io.Writer, s *Struct) error {
+	tmpl, err := template.New("codegen").Funcs(template.FuncMap{
+		"decodable":  decodableType,
+		"decodeStmt": decodeStmt,
+		"fieldFunc":  func(name string) string { return strings.ReplaceAll(name, ".", "") },
+		"quote":      strconv.Quote,
+	}).Parse(tmplSrc)
+	if err != nil {
+		return err
+	}
+
+	data := templateData{Struct: s}
+	for _, f := range s.Fields {
+		if decodableType(f.Type) {
+			data.NeedLoadHelpers = true
+		}
+
+		base := strings.TrimPrefix(f.Type, "*")
+		switch {
+		case base == "[]string":
+			data.NeedStrings = true
+		case base == "string":
+		default:
+			if d, ok := decoders[base]; ok {
+				switch d.pkg {
+				case "strconv":
+					data.NeedStrconv = true
+				case "time":
+					data.NeedTime = true
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("codegen: executing template: %w", err)
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: formatting generated source: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// decodeStmt renders the final `return ...` statement(s) of a Load<Field>
+// helper for typ, decoding the raw string held in src.
+func decodeStmt(typ, src string) (string, error) {
+	if typ == "[]string" {
+		return fmt.Sprintf("return strings.Split(%s, \",\"), nil", src), nil
+	}
+
+	if strings.HasPrefix(typ, "*") {
+		elem := strings.TrimPrefix(typ, "*")
+
+		if elem == "string" {
+			return fmt.Sprintf("v := %s\n\treturn &v, nil", src), nil
+		}
+
+		if elem == "[]string" {
+			return fmt.Sprintf("v := strings.Split(%s, \",\")\n\treturn &v, nil", src), nil
+		}
+
+		d, ok := decoders[elem]
+		if !ok {
+			return "", fmt.Errorf("codegen: no decoder registered for type %s", typ)
+		}
+
+		return fmt.Sprintf(
+			"v, err := %s\n\tif err != nil {\n\t\treturn zero, err\n\t}\n\tpv := %s(v)\n\treturn &pv, nil",
+			fmt.Sprintf(d.expr, src), elem,
+		), nil
+	}
+
+	if typ == "string" {
+		return fmt.Sprintf("return %s, nil", src), nil
+	}
+
+	d, ok := decoders[typ]
+	if !ok {
+		return "", fmt.Errorf("codegen: no decoder registered for type %s", typ)
+	}
+
+	return fmt.Sprintf(
+		"v, err := %s\n\tif err != nil {\n\t\treturn zero, err\n\t}\n\treturn %s(v), nil",
+		fmt.Sprintf(d.expr, src), typ,
+	), nil
+}
@@ -0,0 +1,98 @@
+package codegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTag(t *testing.T) {
+	key, backendName, def, required, ok := parseTag("port,backend=env,default=8080,required")
+	require.True(t, ok)
+	require.Equal(t, "port", key)
+	require.Equal(t, "env", backendName)
+	require.Equal(t, "8080", def)
+	require.True(t, required)
+
+	_, _, _, _, ok = parseTag("-")
+	require.False(t, ok)
+
+	_, _, _, _, ok = parseTag("")
+	require.False(t, ok)
+}
+
+func TestGenerate(t *testing.T) {
+	s := &Struct{
+		Package: "myapp",
+		Name:    "Config",
+		Fields: []Field{
+			{Name: "Port", Key: "port", Type: "int", Default: "8080"},
+			{Name: "Timeout", Key: "timeout", Type: "time.Duration", Required: true},
+			{Name: "Server.Name", Key: "server_name", Type: "string"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Generate(&buf, s)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "package myapp")
+	require.Contains(t, out, "func (*Config) Keys() []codegen.KeyInfo")
+	require.Contains(t, out, "func LoadPort(ctx context.Context, b backend.Backend) (int, error)")
+	require.Contains(t, out, "func LoadTimeout(ctx context.Context, b backend.Backend) (time.Duration, error)")
+	require.Contains(t, out, "func LoadServerName(ctx context.Context, b backend.Backend) (string, error)")
+	require.True(t, strings.Contains(out, `Key: "port"`))
+}
+
+func TestGeneratePointerAndSliceFields(t *testing.T) {
+	s := &Struct{
+		Package: "myapp",
+		Name:    "Config",
+		Fields: []Field{
+			{Name: "MaxConns", Key: "max_conns", Type: "*int"},
+			{Name: "Tags", Key: "tags", Type: "[]string"},
+			{Name: "Note", Key: "note", Type: "*string"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Generate(&buf, s)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, `"strconv"`)
+	require.Contains(t, out, `"strings"`)
+	require.NotContains(t, out, `"time"`)
+
+	require.Contains(t, out, "func LoadMaxConns(ctx context.Context, b backend.Backend) (*int, error)")
+	require.Contains(t, out, "pv := int(v)")
+	require.Contains(t, out, "return &pv, nil")
+
+	require.Contains(t, out, "func LoadTags(ctx context.Context, b backend.Backend) ([]string, error)")
+	require.Contains(t, out, `strings.Split(string(data), ",")`)
+
+	require.Contains(t, out, "func LoadNote(ctx context.Context, b backend.Backend) (*string, error)")
+	require.Contains(t, out, "return &v, nil")
+}
+
+func TestGenerateNoLoadHelpersOmitsUnusedImports(t *testing.T) {
+	s := &Struct{
+		Package: "myapp",
+		Name:    "Config",
+		Fields: []Field{
+			{Name: "Tags", Key: "tags", Type: "map[string]string"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Generate(&buf, s)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.NotContains(t, out, `"context"`)
+	require.NotContains(t, out, `"github.com/heetch/confita/backend"`)
+	require.Contains(t, out, "func (*Config) Keys() []codegen.KeyInfo")
+}
@@ -0,0 +1,110 @@
+package codegen
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Load parses the package at pkgPath and extracts the tagged fields of the
+// struct named structName, following nested structs into dotted key paths.
+func Load(pkgPath, structName string) (*Struct, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+	}
+
+	pkgs, err := packages.Load(cfg, pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("codegen: loading package %q: %w", pkgPath, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("codegen: package %q not found", pkgPath)
+	}
+
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("codegen: %s: %v", pkgPath, pkg.Errors[0])
+	}
+
+	obj := pkg.Types.Scope().Lookup(structName)
+	if obj == nil {
+		return nil, fmt.Errorf("codegen: type %s not found in %s", structName, pkgPath)
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("codegen: %s is not a named type", structName)
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("codegen: %s is not a struct", structName)
+	}
+
+	s := &Struct{
+		Package: pkg.Types.Name(),
+		Name:    structName,
+	}
+
+	fields, err := walkFields(st, "")
+	if err != nil {
+		return nil, err
+	}
+	s.Fields = fields
+
+	return s, nil
+}
+
+// walkFields flattens a struct's tagged fields, recursing into nested
+// struct fields (and non-nil-able pointers to structs) the same way
+// confita.Loader does at runtime.
+func walkFields(st *types.Struct, prefix string) ([]Field, error) {
+	var fields []Field
+
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if !v.Exported() {
+			continue
+		}
+
+		tag := reflect.StructTag(st.Tag(i)).Get("config")
+		name := v.Name()
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		typ := v.Type()
+		under := typ
+		if ptr, ok := under.(*types.Pointer); ok {
+			under = ptr.Elem()
+		}
+
+		if nested, ok := under.Underlying().(*types.Struct); ok {
+			children, err := walkFields(nested, path)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, children...)
+			continue
+		}
+
+		key, backendName, def, required, ok := parseTag(tag)
+		if !ok {
+			continue
+		}
+
+		fields = append(fields, Field{
+			Name:     path,
+			Key:      key,
+			Backend:  backendName,
+			Default:  def,
+			Required: required,
+			Type:     types.TypeString(typ, types.RelativeTo(nil)),
+		})
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,49 @@
+package codegen_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heetch/confita/codegen"
+	"github.com/stretchr/testify/require"
+)
+
+const fixtureSrc = `package fixture
+
+type Nested struct {
+	Name string ` + "`config:\"name\"`" + `
+}
+
+type Config struct {
+	Port    int      ` + "`config:\"port,default=8080\"`" + `
+	Timeout *int     ` + "`config:\"timeout\"`" + `
+	Tags    []string ` + "`config:\"tags\"`" + `
+	Nested  Nested
+}
+`
+
+func TestLoadNestedPointerAndSliceFields(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module fixture\n\ngo 1.20\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixture.go"), []byte(fixtureSrc), 0o644))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	s, err := codegen.Load(".", "Config")
+	require.NoError(t, err)
+
+	byName := make(map[string]codegen.Field)
+	for _, f := range s.Fields {
+		byName[f.Name] = f
+	}
+
+	require.Equal(t, "int", byName["Port"].Type)
+	require.Equal(t, "*int", byName["Timeout"].Type)
+	require.Equal(t, "[]string", byName["Tags"].Type)
+	require.Equal(t, "name", byName["Nested.Name"].Key)
+}
@@ -24,6 +24,8 @@ func (s store) Get(ctx context.Context, key string) ([]byte, error) {
 	return []byte(data), nil
 }
 
+func (s store) String() string { return "store" }
+
 type longRunningStore time.Duration
 
 func (s longRunningStore) Get(ctx context.Context, key string) ([]byte, error) {
@@ -35,12 +37,16 @@ func (s longRunningStore) Get(ctx context.Context, key string) ([]byte, error) {
 	}
 }
 
+func (s longRunningStore) String() string { return "longRunningStore" }
+
 type valueUnmarshaler store
 
 func (k valueUnmarshaler) Get(ctx context.Context, key string) ([]byte, error) {
 	return store(k).Get(ctx, key)
 }
 
+func (k valueUnmarshaler) String() string { return "valueUnmarshaler" }
+
 func (k valueUnmarshaler) UnmarshalValue(ctx context.Context, key string, to interface{}) error {
 	data, err := store(k).Get(ctx, key)
 	if err != nil {
@@ -0,0 +1,293 @@
+// Package confita loads configuration values from multiple backends and
+// merges them into a struct using reflection and struct tags.
+package confita
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heetch/confita/backend"
+)
+
+// tagName is the struct tag used to configure how a field is loaded.
+const tagName = "config"
+
+// Loader loads configuration from a list of backends, queried in the given
+// order. The first backend that returns a value for a key wins.
+type Loader struct {
+	backends []backend.Backend
+
+	// mu guards fields of a struct passed to Watch while it is being
+	// updated concurrently. It is a no-op for plain Load calls.
+	mu sync.RWMutex
+
+	// auditLog, if set with WithAuditLog, is called for every secret
+	// field successfully loaded.
+	auditLog func(field, backendName string)
+}
+
+// NewLoader creates a Loader that reads configuration from the given
+// backends, in order.
+func NewLoader(backends ...backend.Backend) *Loader {
+	return &Loader{
+		backends: backends,
+	}
+}
+
+// WithAuditLog registers fn to be called, without ever being passed the
+// value itself, whenever a field tagged `config:"...,secret"` is
+// successfully loaded, naming the field (its dotted path) and the backend
+// it was read from. It returns l so it can be chained off NewLoader.
+func (l *Loader) WithAuditLog(fn func(field, backendName string)) *Loader {
+	l.auditLog = fn
+	return l
+}
+
+// Load analyzes all the fields of the given struct and loads any matching
+// data from the backends given to NewLoader. Backends that implement
+// backend.BatchGetter are queried once per Load call, up front, for every
+// key they might serve, instead of once per field.
+func (l *Loader) Load(ctx context.Context, to interface{}) error {
+	ref := reflect.ValueOf(to)
+	if ref.Kind() != reflect.Ptr || reflect.Indirect(ref).Kind() != reflect.Struct {
+		return errors.New("confita: target must be a pointer to a struct")
+	}
+
+	fields := l.collectFields(reflect.Indirect(ref), "")
+
+	batches, err := l.prefetchBatches(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if _, err := l.loadField(ctx, f, batches); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prefetchBatches asks every backend.BatchGetter among l.backends for all
+// the keys it might be asked to serve, in one round-trip each. The result
+// is consulted by loadField before falling back to a per-key Get.
+//
+// It is keyed by the backend's position in l.backends rather than by the
+// backend.Backend value itself: backends are free to be unhashable (a
+// store type backed by a map, for instance), and using the interface value
+// as a map key would panic on such a backend.
+func (l *Loader) prefetchBatches(ctx context.Context, fields []watchField) (map[int]map[string][]byte, error) {
+	batches := make(map[int]map[string][]byte)
+
+	for i, b := range l.backends {
+		bg, ok := b.(backend.BatchGetter)
+		if !ok {
+			continue
+		}
+
+		var keys []string
+		for _, f := range fields {
+			if f.opts.backend != "" && f.opts.backend != b.String() {
+				continue
+			}
+			keys = append(keys, f.opts.key)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		data, err := bg.GetMany(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		batches[i] = data
+	}
+
+	return batches, nil
+}
+
+// loadField fetches f's value from the first matching backend and applies
+// it, returning that backend so callers (Watch, in particular) can tell
+// which one actually served the field. It returns a nil backend when the
+// field was left at its tag's default or untouched because it's optional
+// and no backend had it.
+func (l *Loader) loadField(ctx context.Context, f watchField, batches map[int]map[string][]byte) (backend.Backend, error) {
+	opts := f.opts
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, b := range l.backends {
+		if opts.backend != "" && opts.backend != b.String() {
+			continue
+		}
+
+		if prefetched, ok := batches[i]; ok {
+			if data, found := prefetched[opts.key]; found {
+				return b, l.applyValue(ctx, f, b, data)
+			}
+			// the key wasn't in the batch result; still give the backend a
+			// chance to serve it through ValueUnmarshaler below before
+			// moving on to the next backend.
+			if _, ok := b.(backend.ValueUnmarshaler); !ok {
+				continue
+			}
+		}
+
+		if u, ok := b.(backend.ValueUnmarshaler); ok {
+			err := u.UnmarshalValue(ctx, opts.key, f.fv.Addr().Interface())
+			if err == nil {
+				if opts.secret && l.auditLog != nil {
+					l.auditLog(f.path, b.String())
+				}
+				return b, nil
+			}
+			if err != backend.ErrNotFound {
+				return nil, err
+			}
+			continue
+		}
+
+		data, err := b.Get(ctx, opts.key)
+		if err == nil {
+			return b, l.applyValue(ctx, f, b, data)
+		}
+		if err != backend.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	if opts.def != "" {
+		return nil, setFieldValue(f.fv, []byte(opts.def))
+	}
+
+	if opts.required {
+		return nil, fmt.Errorf("confita: required key %q could not be found in any backend", opts.key)
+	}
+
+	return nil, nil
+}
+
+// applyValue sets f's value from data, decrypting it first if f is tagged
+// secret and b knows how to. For secret fields, once the value has been
+// copied into f, data is zeroed so the plaintext doesn't linger in memory,
+// and the loader's audit log (if any) is notified.
+func (l *Loader) applyValue(ctx context.Context, f watchField, b backend.Backend, data []byte) error {
+	if f.opts.secret {
+		if d, ok := b.(backend.Decrypter); ok {
+			plain, err := d.Decrypt(ctx, f.opts.key, data)
+			if err != nil {
+				return err
+			}
+			data = plain
+		}
+	}
+
+	if err := setFieldValue(f.fv, data); err != nil {
+		return err
+	}
+
+	if f.opts.secret {
+		if l.auditLog != nil {
+			l.auditLog(f.path, b.String())
+		}
+		zero(data)
+	}
+
+	return nil
+}
+
+// fieldOpts holds the parsed content of a config struct tag.
+type fieldOpts struct {
+	key      string
+	backend  string
+	def      string
+	required bool
+	secret   bool
+}
+
+func parseTag(tag string, ok bool) (fieldOpts, bool) {
+	if !ok || tag == "" {
+		return fieldOpts{}, false
+	}
+
+	parts := strings.Split(tag, ",")
+
+	opts := fieldOpts{key: parts[0]}
+
+	for _, p := range parts[1:] {
+		switch {
+		case p == "required":
+			opts.required = true
+		case p == "secret":
+			opts.secret = true
+		case strings.HasPrefix(p, "backend="):
+			opts.backend = strings.TrimPrefix(p, "backend=")
+		case strings.HasPrefix(p, "default="):
+			opts.def = strings.TrimPrefix(p, "default=")
+		}
+	}
+
+	return opts, true
+}
+
+func setFieldValue(v reflect.Value, data []byte) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	s := string(data)
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.String:
+		v.SetString(s)
+	default:
+		return fmt.Errorf("confita: unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
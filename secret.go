@@ -0,0 +1,37 @@
+package confita
+
+// Secret wraps a configuration value loaded from a field tagged
+// `config:"...,secret"` so that it can't be leaked by accident: printing it
+// with fmt, logging it, or encoding it to JSON or text all yield a redacted
+// placeholder. Call Reveal to get the real value back.
+type Secret string
+
+// String implements fmt.Stringer. It never returns the underlying value.
+func (s Secret) String() string {
+	return "***"
+}
+
+// MarshalJSON implements json.Marshaler. It never encodes the underlying
+// value.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"***"`), nil
+}
+
+// MarshalText implements encoding.TextMarshaler. It never encodes the
+// underlying value.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte("***"), nil
+}
+
+// Reveal returns the underlying secret value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// zero overwrites b in place so a decrypted secret doesn't linger in memory
+// any longer than necessary once it has been copied into a Secret field.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,89 @@
+package confita_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// decryptingStore serves ciphertext that must be passed through Decrypt
+// before it matches the plaintext value under test.
+type decryptingStore struct {
+	ciphertext map[string]string
+	plaintext  map[string]string
+}
+
+func (s decryptingStore) String() string { return "vault" }
+
+func (s decryptingStore) Get(ctx context.Context, key string) ([]byte, error) {
+	v, ok := s.ciphertext[key]
+	if !ok {
+		return nil, backend.ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func (s decryptingStore) Decrypt(ctx context.Context, key string, ciphertext []byte) ([]byte, error) {
+	return []byte(s.plaintext[key]), nil
+}
+
+func TestLoadSecretIsDecryptedAndRedacted(t *testing.T) {
+	s := struct {
+		Password confita.Secret `config:"password,secret"`
+	}{}
+
+	store := decryptingStore{
+		ciphertext: map[string]string{"password": "enc(hunter2)"},
+		plaintext:  map[string]string{"password": "hunter2"},
+	}
+
+	var audited []string
+	loader := confita.NewLoader(store).WithAuditLog(func(field, backendName string) {
+		audited = append(audited, field+"@"+backendName)
+	})
+
+	err := loader.Load(context.Background(), &s)
+	require.NoError(t, err)
+
+	require.Equal(t, "hunter2", s.Password.Reveal())
+	require.Equal(t, "***", s.Password.String())
+	require.Equal(t, []string{"Password@vault"}, audited)
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Password":"***"}`, string(data))
+}
+
+// TestLoadSecretAlongsideUnhashableBackend is a regression test for a bug
+// where confita.Loader's batch prefetch keyed an internal map by the
+// backend.Backend value itself: decryptingStore (like most hand-rolled
+// backends, e.g. the baseline store map[string]string) holds map fields and
+// is therefore unhashable, which used to panic as soon as any other
+// backend in the same Loader implemented backend.BatchGetter.
+func TestLoadSecretAlongsideUnhashableBackend(t *testing.T) {
+	s := struct {
+		Password confita.Secret `config:"password,secret"`
+		Other    string         `config:"other"`
+	}{}
+
+	secretStore := decryptingStore{
+		ciphertext: map[string]string{"password": "enc(hunter2)"},
+		plaintext:  map[string]string{"password": "hunter2"},
+	}
+
+	batchStore := &countingBatchStore{data: map[string]string{"other": "value"}}
+
+	loader := confita.NewLoader(secretStore, batchStore)
+
+	require.NotPanics(t, func() {
+		err := loader.Load(context.Background(), &s)
+		require.NoError(t, err)
+	})
+
+	require.Equal(t, "hunter2", s.Password.Reveal())
+	require.Equal(t, "value", s.Other)
+}
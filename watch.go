@@ -0,0 +1,299 @@
+package confita
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/heetch/confita/backend"
+)
+
+// DefaultPollInterval is the interval at which backends that don't
+// implement backend.Watcher are polled for changes, unless overridden with
+// WithPollInterval.
+const DefaultPollInterval = 30 * time.Second
+
+// WatchOption configures the behavior of Loader.Watch.
+type WatchOption func(*watchOpts)
+
+type watchOpts struct {
+	pollInterval time.Duration
+}
+
+// WithPollInterval overrides DefaultPollInterval for backends that don't
+// implement backend.Watcher.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(o *watchOpts) {
+		o.pollInterval = d
+	}
+}
+
+// watchField is a flattened, addressable config field found while walking
+// the target struct, along with the options parsed from its tag.
+type watchField struct {
+	path string
+	fv   reflect.Value
+	opts fieldOpts
+}
+
+// Snapshot runs fn while holding a read lock, giving callers a safe way to
+// read a struct that is concurrently being updated by Watch.
+func (l *Loader) Snapshot(fn func()) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	fn()
+}
+
+// Watch loads cfg once and then keeps it up to date, invoking callback with
+// the dotted paths of the fields that changed every time new data is
+// observed. Fields backed by a backend.Watcher are updated as soon as the
+// backend pushes a change; every other field is polled every
+// DefaultPollInterval, or the interval given with WithPollInterval. Watch blocks until ctx is canceled, at which point it
+// returns ctx.Err(). Reads of cfg made from another goroutine while Watch
+// is running should go through Snapshot.
+func (l *Loader) Watch(ctx context.Context, cfg interface{}, callback func(changed []string), opts ...WatchOption) error {
+	o := watchOpts{pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Ptr || reflect.Indirect(rv).Kind() != reflect.Struct {
+		return errors.New("confita: target must be a pointer to a struct")
+	}
+
+	ref := reflect.Indirect(rv)
+	fields := l.collectFields(ref, "")
+
+	batches, err := l.prefetchBatches(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	// owners records, for each field, the backend that actually served its
+	// initial value, so the watcher below subscribes through that same
+	// backend instead of whichever backend in l.backends happens to
+	// implement backend.Watcher first.
+	owners := make(map[string]backend.Backend, len(fields))
+	for _, f := range fields {
+		b, err := l.loadField(ctx, f, batches)
+		if err != nil {
+			return err
+		}
+		owners[f.path] = b
+	}
+
+	changes := make(chan string)
+	var wg sync.WaitGroup
+
+	var watched int
+	for _, f := range fields {
+		if _, ok := owners[f.path].(backend.Watcher); ok {
+			watched++
+		}
+	}
+	// buffered so a failing watchField never blocks trying to report its
+	// error after the first one has already made Watch return.
+	watchErrs := make(chan error, watched)
+
+	for _, f := range fields {
+		w, ok := owners[f.path].(backend.Watcher)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(f watchField, b backend.Watcher) {
+			defer wg.Done()
+			l.watchField(ctx, f, b, changes, watchErrs)
+		}(f, w)
+	}
+
+	polled := make([]watchField, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := owners[f.path].(backend.Watcher); !ok {
+			polled = append(polled, f)
+		}
+	}
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		var batch []string
+		flush := time.NewTicker(50 * time.Millisecond)
+		defer flush.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case path := <-changes:
+				batch = append(batch, path)
+			case <-flush.C:
+				if len(batch) > 0 {
+					callback(batch)
+					batch = nil
+				}
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case err := <-watchErrs:
+			wg.Wait()
+			return err
+		case <-ticker.C:
+			if len(polled) == 0 {
+				continue
+			}
+			changed, err := l.reloadFields(ctx, polled)
+			if err != nil {
+				return err
+			}
+			// Routed through the same changes channel as pushed updates, so
+			// the flush goroutine above is the only caller of callback and
+			// polled and pushed changes can never invoke it concurrently.
+			for _, path := range changed {
+				select {
+				case changes <- path:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+}
+
+// watchField listens for pushed updates for a single field until ctx is
+// canceled or b.Watch returns, applying each update under the loader's
+// lock. A non-context-cancellation error from b.Watch is sent on errs so
+// the caller's Watch can return it instead of the field silently going
+// quiet.
+func (l *Loader) watchField(ctx context.Context, f watchField, b backend.Watcher, changes chan<- string, errs chan<- error) {
+	ch := make(chan []byte)
+	done := make(chan error, 1)
+
+	go func() { done <- b.Watch(ctx, f.opts.key, ch) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-done:
+			if err != nil && err != ctx.Err() {
+				errs <- err
+			}
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			// b came from the owners map in Watch, so it is also a
+			// backend.Backend; applyValue decrypts secret fields.
+			l.mu.Lock()
+			err := l.applyValue(ctx, f, b.(backend.Backend), data)
+			l.mu.Unlock()
+			if err != nil {
+				continue
+			}
+
+			select {
+			case changes <- f.path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// reloadFields re-fetches each of the given fields and reports the dotted
+// paths of those whose value changed.
+func (l *Loader) reloadFields(ctx context.Context, fields []watchField) ([]string, error) {
+	var changed []string
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, f := range fields {
+		before := snapshotValue(f.fv)
+
+		if _, err := l.loadField(ctx, f, nil); err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(before, snapshotValue(f.fv)) {
+			changed = append(changed, f.path)
+		}
+	}
+
+	return changed, nil
+}
+
+// snapshotValue copies out the current value of fv for later comparison
+// with reflect.DeepEqual. For pointer fields, f.fv.Interface() alone isn't
+// enough: setFieldValue mutates the pointee in place rather than swapping
+// in a new pointer, so two snapshots taken through the pointer would always
+// compare equal. Dereferencing instead copies the pointee's value as it was
+// at the time of the snapshot.
+func snapshotValue(fv reflect.Value) interface{} {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		return fv.Elem().Interface()
+	}
+	return fv.Interface()
+}
+
+// collectFields flattens a struct into the list of addressable, tagged
+// fields it contains, recursing into nested structs the same way Load does
+// and building dotted paths as it goes.
+func (l *Loader) collectFields(v reflect.Value, prefix string) []watchField {
+	var fields []watchField
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+
+		field := t.Field(i)
+		path := field.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		opts, hasTag := parseTag(field.Tag.Lookup(tagName))
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			fields = append(fields, l.collectFields(fv, path)...)
+			continue
+		case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+			if fv.IsNil() {
+				continue
+			}
+			fields = append(fields, l.collectFields(fv.Elem(), path)...)
+			continue
+		}
+
+		if !hasTag || opts.key == "-" {
+			continue
+		}
+
+		fields = append(fields, watchField{path: path, fv: fv, opts: opts})
+	}
+
+	return fields
+}
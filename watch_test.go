@@ -0,0 +1,244 @@
+package confita_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// pollableBackend is a Backend that does not implement backend.Watcher, so
+// Loader.Watch must fall back to polling it.
+type pollableBackend struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (b *pollableBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	v, ok := b.data[key]
+	if !ok {
+		return nil, backend.ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func (b *pollableBackend) String() string { return "pollable" }
+
+func (b *pollableBackend) set(key, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[key] = value
+}
+
+// pushBackend implements backend.Watcher and pushes values as soon as they
+// are set, without waiting for a poll tick.
+type pushBackend struct {
+	ch chan []byte
+}
+
+func (b *pushBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return []byte("initial"), nil
+}
+
+func (b *pushBackend) String() string { return "push" }
+
+func (b *pushBackend) Watch(ctx context.Context, key string, ch chan<- []byte) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case v := <-b.ch:
+			ch <- v
+		}
+	}
+}
+
+func TestWatchPolling(t *testing.T) {
+	cfg := struct {
+		Name string `config:"name"`
+	}{}
+
+	b := &pollableBackend{data: map[string]string{"name": "first"}}
+	loader := confita.NewLoader(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var mu sync.Mutex
+	var seen []string
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		b.set("name", "second")
+	}()
+
+	err := loader.Watch(ctx, &cfg, func(changed []string) {
+		mu.Lock()
+		seen = append(seen, changed...)
+		mu.Unlock()
+	}, confita.WithPollInterval(10*time.Millisecond))
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, seen, "Name")
+
+	loader.Snapshot(func() {
+		require.Equal(t, "second", cfg.Name)
+	})
+}
+
+func TestWatchPollingDetectsPointerFieldChange(t *testing.T) {
+	cfg := struct {
+		Name *string `config:"name"`
+	}{}
+
+	b := &pollableBackend{data: map[string]string{"name": "first"}}
+	loader := confita.NewLoader(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var mu sync.Mutex
+	var seen []string
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		b.set("name", "second")
+	}()
+
+	err := loader.Watch(ctx, &cfg, func(changed []string) {
+		mu.Lock()
+		seen = append(seen, changed...)
+		mu.Unlock()
+	}, confita.WithPollInterval(10*time.Millisecond))
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, seen, "Name")
+
+	loader.Snapshot(func() {
+		require.Equal(t, "second", *cfg.Name)
+	})
+}
+
+func TestWatchPush(t *testing.T) {
+	cfg := struct {
+		Name string `config:"name"`
+	}{}
+
+	b := &pushBackend{ch: make(chan []byte, 1)}
+	loader := confita.NewLoader(b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	watchErr := make(chan error, 1)
+
+	go func() {
+		watchErr <- loader.Watch(ctx, &cfg, func(changed []string) {
+			close(done)
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	b.ch <- []byte("pushed")
+
+	select {
+	case <-done:
+	case <-time.After(250 * time.Millisecond):
+		t.Fatal("callback was never invoked for pushed update")
+	}
+
+	// Cancel and wait for Watch to return before making any assertions, so
+	// nothing in the background goroutine can still be running, and
+	// testing.T is never touched, after this test function returns.
+	cancel()
+
+	select {
+	case err := <-watchErr:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(250 * time.Millisecond):
+		t.Fatal("Watch did not return after cancel")
+	}
+
+	loader.Snapshot(func() {
+		require.Equal(t, "pushed", cfg.Name)
+	})
+}
+
+func TestWatchHonorsBackendPrecedenceForWatcher(t *testing.T) {
+	cfg := struct {
+		Name string `config:"name"`
+	}{}
+
+	// b serves "name" under normal Load precedence and does not implement
+	// backend.Watcher; w is lower precedence but does. Watch must poll b for
+	// this field instead of subscribing to w's pushes, even though w is a
+	// Watcher and b isn't.
+	b := &pollableBackend{data: map[string]string{"name": "first"}}
+	w := &pushBackend{ch: make(chan []byte, 1)}
+	loader := confita.NewLoader(b, w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	w.ch <- []byte("from-etcd")
+
+	err := loader.Watch(ctx, &cfg, func(changed []string) {}, confita.WithPollInterval(10*time.Millisecond))
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	loader.Snapshot(func() {
+		require.Equal(t, "first", cfg.Name)
+	})
+}
+
+func TestWatchSerializesCallbackAcrossPushAndPoll(t *testing.T) {
+	cfg := struct {
+		Polled string `config:"polled,backend=pollable"`
+		Pushed string `config:"pushed,backend=push"`
+	}{}
+
+	pb := &pollableBackend{data: map[string]string{"polled": "first"}}
+	pu := &pushBackend{ch: make(chan []byte, 1)}
+	loader := confita.NewLoader(pb, pu)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pb.set("polled", "second")
+		pu.ch <- []byte("pushed")
+	}()
+
+	var mu sync.Mutex
+	var inCallback, overlapped bool
+
+	err := loader.Watch(ctx, &cfg, func(changed []string) {
+		mu.Lock()
+		if inCallback {
+			overlapped = true
+		}
+		inCallback = true
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inCallback = false
+		mu.Unlock()
+	}, confita.WithPollInterval(10*time.Millisecond))
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	require.False(t, overlapped, "callback was invoked concurrently from the push and poll paths")
+}